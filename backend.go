@@ -0,0 +1,136 @@
+package leaf
+
+import (
+    "errors"
+
+    "github.com/boltdb/bolt"
+)
+
+// ErrUnknownBackend is returned by OpenBackend when no backend has been registered under
+// the requested name
+var ErrUnknownBackend = errors.New("Unknown backend")
+
+// Options configures a backend opened through OpenBackend. Not every field is meaningful
+// to every backend; a backend ignores fields it doesn't need.
+type Options struct {
+    // Path is the filesystem path used by disk-backed backends, such as the bolt backend's
+    // database file.
+    Path string
+}
+
+// BackendOpener constructs a KeyValueDatabase for a registered backend.
+type BackendOpener func(opts Options) (KeyValueDatabase, error)
+
+// backends holds the registered BackendOpeners, keyed by name.
+var backends = map[string]BackendOpener{}
+
+// RegisterBackend makes a backend available under name for use with OpenBackend. It is
+// typically called from a backend's init function, mirroring how Tendermint's db package
+// registers its memdb, fsdb, and goleveldb backends.
+func RegisterBackend(name string, opener BackendOpener) {
+    backends[name] = opener
+}
+
+// OpenBackend opens a KeyValueDatabase using the backend registered under name
+func OpenBackend(name string, opts Options) (KeyValueDatabase, error) {
+    opener, ok := backends[name]
+    if !ok {
+        return nil, ErrUnknownBackend
+    }
+    return opener(opts)
+}
+
+// Tx is a backend-neutral transaction handle for a single key/value bucket, used by
+// ReadTx and WriteTx so callers aren't locked to a particular backend's storage type.
+type Tx interface {
+
+    // Get returns the value for key, or ErrKeyNotFound if it doesn't exist
+    Get(key []byte) ([]byte, error)
+
+    // Put sets key to value
+    Put(key, value []byte) error
+
+    // Delete removes key
+    Delete(key []byte) error
+
+    // Cursor returns a Cursor over the bucket backing this Tx
+    Cursor() Cursor
+}
+
+// Cursor is a backend-neutral, ordered cursor over a bucket's key/value pairs.
+type Cursor interface {
+
+    // First moves the cursor to the first key/value pair and returns it
+    First() (key, value []byte)
+
+    // Last moves the cursor to the last key/value pair and returns it
+    Last() (key, value []byte)
+
+    // Seek moves the cursor to the first key/value pair whose key is >= seek
+    Seek(seek []byte) (key, value []byte)
+
+    // Next moves the cursor to the next key/value pair and returns it
+    Next() (key, value []byte)
+
+    // Prev moves the cursor to the previous key/value pair and returns it
+    Prev() (key, value []byte)
+}
+
+// boltTx adapts a *bolt.Bucket to the backend-neutral Tx interface
+type boltTx struct {
+    bkt *bolt.Bucket
+}
+
+// Get returns the value for key, or ErrKeyNotFound if it doesn't exist
+func (t *boltTx) Get(key []byte) ([]byte, error) {
+    value := t.bkt.Get(key)
+    if value == nil {
+        return nil, ErrKeyNotFound
+    }
+    return value, nil
+}
+
+// Put sets key to value
+func (t *boltTx) Put(key, value []byte) error {
+    return t.bkt.Put(key, value)
+}
+
+// Delete removes key
+func (t *boltTx) Delete(key []byte) error {
+    return t.bkt.Delete(key)
+}
+
+// Cursor returns a Cursor over the bucket backing this Tx
+func (t *boltTx) Cursor() Cursor {
+    return &boltCursor{t.bkt.Cursor()}
+}
+
+// boltCursor adapts a *bolt.Cursor to the backend-neutral Cursor interface
+type boltCursor struct {
+    cursor *bolt.Cursor
+}
+
+// First moves the cursor to the first key/value pair and returns it
+func (c *boltCursor) First() ([]byte, []byte) {
+    return c.cursor.First()
+}
+
+// Last moves the cursor to the last key/value pair and returns it
+func (c *boltCursor) Last() ([]byte, []byte) {
+    return c.cursor.Last()
+}
+
+// Seek moves the cursor to the first key/value pair whose key is >= seek
+func (c *boltCursor) Seek(seek []byte) ([]byte, []byte) {
+    return c.cursor.Seek(seek)
+}
+
+// Next moves the cursor to the next key/value pair and returns it
+func (c *boltCursor) Next() ([]byte, []byte) {
+    return c.cursor.Next()
+}
+
+// Prev moves the cursor to the previous key/value pair and returns it
+func (c *boltCursor) Prev() ([]byte, []byte) {
+    return c.cursor.Prev()
+}
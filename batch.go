@@ -0,0 +1,100 @@
+package leaf
+
+import (
+    "github.com/boltdb/bolt"
+)
+
+// Batch queues a series of mutations against a Keyspace so they can be committed
+// atomically in a single write transaction, amortizing the fsync cost of bulk loads.
+// Without it, every Insert/Update/Delete opens its own write transaction, which is a
+// major throughput bottleneck when applying hundreds or thousands of writes.
+type Batch interface {
+
+    // Set queues a key to be set to value when the batch is written
+    Set(key string, value []byte)
+
+    // Delete queues a key to be removed when the batch is written
+    Delete(key string)
+
+    // Write commits all queued mutations inside a single write transaction
+    Write() error
+
+    // Close discards the batch, releasing any queued mutations
+    Close()
+}
+
+// batchOp is a single queued mutation in a boltBatch
+type batchOp struct {
+    delete bool
+    key    string
+    value  []byte
+}
+
+// boltBatch implements Batch on top of a BoltKeyspace
+type boltBatch struct {
+    ks  *BoltKeyspace
+    ops []batchOp
+}
+
+// NewBatch returns a Batch that queues mutations against the keyspace
+func (b *BoltKeyspace) NewBatch() Batch {
+    return &boltBatch{ks: b}
+}
+
+// Set queues a key to be set to value when the batch is written
+func (batch *boltBatch) Set(key string, value []byte) {
+    batch.ops = append(batch.ops, batchOp{key: key, value: value})
+}
+
+// Delete queues a key to be removed when the batch is written
+func (batch *boltBatch) Delete(key string) {
+    batch.ops = append(batch.ops, batchOp{delete: true, key: key})
+}
+
+// Write commits all queued mutations inside a single write transaction, then fans out a
+// watch Event for each one now that the transaction has committed.
+func (batch *boltBatch) Write() error {
+    events := make([]Event, 0, len(batch.ops))
+
+    hub := batch.ks.hub()
+    hub.writeMu.Lock()
+    defer hub.writeMu.Unlock()
+
+    err := batch.ks.db.Update(func(tx *bolt.Tx) error {
+        bkt, err := batch.ks.bucket(tx)
+        if err != nil {
+            return err
+        }
+
+        for _, op := range batch.ops {
+            prev := copyBytes(bkt.Get([]byte(op.key)))
+
+            if op.delete {
+                if err := bkt.Delete([]byte(op.key)); err != nil {
+                    return err
+                }
+                events = append(events, Event{Type: EventDelete, Key: []byte(op.key), PrevValue: prev})
+                continue
+            }
+
+            if err := bkt.Put([]byte(op.key), op.value); err != nil {
+                return err
+            }
+            events = append(events, Event{Type: EventPut, Key: []byte(op.key), Value: op.value, PrevValue: prev})
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+
+    for _, e := range events {
+        hub.emit(e)
+    }
+    return nil
+}
+
+// Close discards the batch, releasing any queued mutations
+func (batch *boltBatch) Close() {
+    batch.ops = nil
+}
@@ -0,0 +1,73 @@
+package leaf
+
+import (
+    "os"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestBoltKeyspaceBatchWrite(t *testing.T) {
+    file := os.TempDir() + "/leaf.db"
+
+    leaf, err := NewLeaf(file)
+    defer leaf.Close()
+
+    assert.Equal(t, nil, err)
+    assert.NotEqual(t, nil, leaf)
+
+    ks, err := leaf.GetOrCreateKeyspace("users")
+    assert.Equal(t, nil, err)
+    assert.NotEqual(t, nil, ks)
+
+    err = ks.Insert("user1", []byte("stale"))
+    assert.Nil(t, err)
+
+    batch := ks.NewBatch()
+    batch.Set("user1", []byte("1"))
+    batch.Set("user2", []byte("2"))
+    batch.Delete("user1")
+    batch.Set("user3", []byte("3"))
+
+    err = batch.Write()
+    assert.Nil(t, err)
+    batch.Close()
+
+    // user1 was set then deleted in the same batch
+    _, err = ks.Get("user1")
+    assert.Equal(t, ErrKeyNotFound, err)
+
+    value, err := ks.Get("user2")
+    assert.Nil(t, err)
+    assert.Equal(t, []byte("2"), value)
+
+    value, err = ks.Get("user3")
+    assert.Nil(t, err)
+    assert.Equal(t, []byte("3"), value)
+
+    leaf.DeleteKeyspace("users")
+}
+
+func TestBoltKeyspaceBatchCloseDiscardsQueuedOps(t *testing.T) {
+    file := os.TempDir() + "/leaf.db"
+
+    leaf, err := NewLeaf(file)
+    defer leaf.Close()
+
+    assert.Equal(t, nil, err)
+
+    ks, err := leaf.GetOrCreateKeyspace("users")
+    assert.Equal(t, nil, err)
+
+    batch := ks.NewBatch()
+    batch.Set("user1", []byte("1"))
+    batch.Close()
+
+    err = batch.Write()
+    assert.Nil(t, err)
+
+    _, err = ks.Get("user1")
+    assert.Equal(t, ErrKeyNotFound, err)
+
+    leaf.DeleteKeyspace("users")
+}
@@ -0,0 +1,458 @@
+package leaf
+
+import (
+    "bytes"
+    "errors"
+    "sync"
+)
+
+// ErrCacheWrapStructuralChange is returned by GetOrCreateChild and DeleteChild on a
+// CacheWrap. Child creation/deletion is not staged by the overlay, so allowing it would
+// silently defeat the abortability CacheWrap promises; callers needing to create or
+// delete children as part of an abortable operation must do so on the underlying
+// keyspace outside the overlay.
+var ErrCacheWrapStructuralChange = errors.New("CacheWrap does not support staged child creation or deletion")
+
+// CacheWrap is an in-memory overlay over a Keyspace that stages Insert/Update/Delete
+// operations instead of applying them immediately, as in Tendermint's CacheDB. Reads
+// consult the overlay first, including tombstones for deleted keys, and fall through to
+// the parent keyspace on a miss. This gives multi-step operations a way to be aborted
+// without leaving partial state behind.
+type CacheWrap interface {
+    Keyspace
+
+    // Write flushes all buffered mutations to the parent keyspace in a single batch
+    Write() error
+
+    // Discard drops all buffered mutations without touching the parent keyspace
+    Discard()
+}
+
+// CacheWrap returns an in-memory overlay over the keyspace
+func (b *BoltKeyspace) CacheWrap() CacheWrap {
+    return newCacheKeyspace(b)
+}
+
+// CacheWrap returns an in-memory overlay over the keyspace
+func (k *memoryKeyspace) CacheWrap() CacheWrap {
+    return newCacheKeyspace(k)
+}
+
+// cacheKeyspace implements CacheWrap. puts and deletes are disjoint: writing a key clears
+// any tombstone for it, and deleting a key clears any staged write for it.
+type cacheKeyspace struct {
+    mu      sync.RWMutex
+    parent  Keyspace
+    puts    map[string][]byte
+    deletes map[string]bool
+}
+
+func newCacheKeyspace(parent Keyspace) CacheWrap {
+    return &cacheKeyspace{
+        parent:  parent,
+        puts:    make(map[string][]byte),
+        deletes: make(map[string]bool),
+    }
+}
+
+// GetName returns the name of the underlying keyspace
+func (c *cacheKeyspace) GetName() string {
+    return c.parent.GetName()
+}
+
+// List finds all the keys listed and calls the function provided with the key value pairs
+func (c *cacheKeyspace) List(keys []string, callback func(k, v []byte)) error {
+    if len(keys) == 0 {
+        return ErrEmptyKeyList
+    }
+
+    for _, key := range keys {
+        if value, err := c.Get(key); err == nil {
+            callback([]byte(key), value)
+        }
+    }
+    return nil
+}
+
+// Insert stages a key value pair, to be applied to the parent keyspace on Write
+func (c *cacheKeyspace) Insert(key string, value []byte) error {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    delete(c.deletes, key)
+    c.puts[key] = value
+    return nil
+}
+
+// Get returns the staged value for key if present, a not-found error if it is staged for
+// deletion, or falls through to the parent keyspace
+func (c *cacheKeyspace) Get(key string) ([]byte, error) {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+
+    if c.deletes[key] {
+        return nil, ErrKeyNotFound
+    }
+    if value, ok := c.puts[key]; ok {
+        return value, nil
+    }
+    return c.parent.Get(key)
+}
+
+// Update stages a key value pair, to be applied to the parent keyspace on Write
+func (c *cacheKeyspace) Update(key string, value []byte) error {
+    return c.Insert(key, value)
+}
+
+// Delete stages the removal of a key, to be applied to the parent keyspace on Write
+func (c *cacheKeyspace) Delete(key string) error {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    delete(c.puts, key)
+    c.deletes[key] = true
+    return nil
+}
+
+// Size returns the number of items the keyspace would have if Write were called now
+func (c *cacheKeyspace) Size() int64 {
+    c.mu.RLock()
+    puts := make(map[string][]byte, len(c.puts))
+    for key, value := range c.puts {
+        puts[key] = value
+    }
+    deletes := make(map[string]bool, len(c.deletes))
+    for key := range c.deletes {
+        deletes[key] = true
+    }
+    c.mu.RUnlock()
+
+    size := c.parent.Size()
+    for key := range deletes {
+        if ok, _ := c.parent.Contains(key); ok {
+            size--
+        }
+    }
+    for key := range puts {
+        if ok, _ := c.parent.Contains(key); !ok {
+            size++
+        }
+    }
+    return size
+}
+
+// ForEach iterates over every key value pair the keyspace would have if Write were called
+// now, consulting staged writes and tombstones before falling through to the parent
+func (c *cacheKeyspace) ForEach(each ItemHandler) error {
+    c.mu.RLock()
+    puts := make(map[string][]byte, len(c.puts))
+    for key, value := range c.puts {
+        puts[key] = value
+    }
+    deletes := make(map[string]bool, len(c.deletes))
+    for key := range c.deletes {
+        deletes[key] = true
+    }
+    c.mu.RUnlock()
+
+    visited := make(map[string]bool, len(puts))
+    err := c.parent.ForEach(func(k, v []byte) error {
+        key := string(k)
+        if deletes[key] {
+            return nil
+        }
+        if overlay, ok := puts[key]; ok {
+            visited[key] = true
+            return each(k, overlay)
+        }
+        return each(k, v)
+    })
+    if err != nil {
+        return err
+    }
+
+    for key, value := range puts {
+        if visited[key] {
+            continue
+        }
+        if err := each([]byte(key), value); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// Contains determines if the given key would exist if Write were called now
+func (c *cacheKeyspace) Contains(key string) (bool, error) {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+
+    if c.deletes[key] {
+        return false, nil
+    }
+    if _, ok := c.puts[key]; ok {
+        return true, nil
+    }
+    return c.parent.Contains(key)
+}
+
+// ReadTx bypasses the overlay and reads directly from the parent keyspace; only
+// Insert/Update/Delete participate in staging.
+func (c *cacheKeyspace) ReadTx(callback func(Tx) error) error {
+    return c.parent.ReadTx(callback)
+}
+
+// WriteTx bypasses the overlay and writes directly to the parent keyspace; only
+// Insert/Update/Delete participate in staging.
+func (c *cacheKeyspace) WriteTx(callback func(Tx) error) error {
+    return c.parent.WriteTx(callback)
+}
+
+// GetOrCreateChild always fails: creating the child bucket isn't staged by the overlay,
+// so it can't be made to participate in Discard/Write like Insert/Update/Delete can.
+func (c *cacheKeyspace) GetOrCreateChild(name string) (Keyspace, error) {
+    return nil, ErrCacheWrapStructuralChange
+}
+
+// DeleteChild always fails: removing a child bucket isn't staged by the overlay, so it
+// can't be made to participate in Discard/Write like Insert/Update/Delete can.
+func (c *cacheKeyspace) DeleteChild(name string) error {
+    return ErrCacheWrapStructuralChange
+}
+
+// ForEachChild iterates over the direct child keyspaces nested under the parent, each
+// wrapped in its own cache overlay.
+func (c *cacheKeyspace) ForEachChild(each func(name string, ks Keyspace) error) error {
+    return c.parent.ForEachChild(func(name string, ks Keyspace) error {
+        return each(name, ks.CacheWrap())
+    })
+}
+
+// Iterator returns an Iterator over the range [start, end), merging staged writes and
+// tombstones over the parent keyspace's range
+func (c *cacheKeyspace) Iterator(start, end []byte) (Iterator, error) {
+    return c.mergeIterator(start, end, false)
+}
+
+// ReverseIterator returns a reverse Iterator over the range [start, end), merging staged
+// writes and tombstones over the parent keyspace's range
+func (c *cacheKeyspace) ReverseIterator(start, end []byte) (Iterator, error) {
+    return c.mergeIterator(start, end, true)
+}
+
+// PrefixIterator returns an Iterator over every key beginning with prefix, merging staged
+// writes and tombstones over the parent keyspace
+func (c *cacheKeyspace) PrefixIterator(prefix []byte) (Iterator, error) {
+    return c.mergeIterator(prefix, prefixRangeEnd(prefix), false)
+}
+
+func (c *cacheKeyspace) mergeIterator(start, end []byte, reverse bool) (Iterator, error) {
+    c.mu.RLock()
+    puts := make(map[string][]byte, len(c.puts))
+    for key, value := range c.puts {
+        puts[key] = value
+    }
+    deletes := make(map[string]bool, len(c.deletes))
+    for key := range c.deletes {
+        deletes[key] = true
+    }
+    c.mu.RUnlock()
+
+    var parentIt Iterator
+    var err error
+    if reverse {
+        parentIt, err = c.parent.ReverseIterator(start, end)
+    } else {
+        parentIt, err = c.parent.Iterator(start, end)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    overlayIt := newMemoryIterator(puts, start, end, reverse)
+
+    it := &cacheMergeIterator{parent: parentIt, overlay: overlayIt, deletes: deletes, reverse: reverse}
+    it.advance()
+    return it, nil
+}
+
+// NewBatch returns a Batch that stages its mutations into the overlay rather than
+// applying them to the parent keyspace; they are flushed together on Write.
+func (c *cacheKeyspace) NewBatch() Batch {
+    return &cacheBatch{ks: c}
+}
+
+// CacheWrap returns a nested overlay on top of this one; writes only reach the parent once
+// every layer above it has been flushed with Write.
+func (c *cacheKeyspace) CacheWrap() CacheWrap {
+    return newCacheKeyspace(c)
+}
+
+// Watch subscribes to every subsequent Put/Delete on the parent keyspace whose key matches
+// prefix. Staged mutations only emit once Write flushes them to the parent.
+func (c *cacheKeyspace) Watch(prefix string) (<-chan Event, CancelFunc) {
+    return c.parent.Watch(prefix)
+}
+
+// Write flushes all staged mutations to the parent keyspace in a single batch
+func (c *cacheKeyspace) Write() error {
+    c.mu.Lock()
+    puts := c.puts
+    deletes := c.deletes
+    c.puts = make(map[string][]byte)
+    c.deletes = make(map[string]bool)
+    c.mu.Unlock()
+
+    batch := c.parent.NewBatch()
+    defer batch.Close()
+
+    for key := range deletes {
+        batch.Delete(key)
+    }
+    for key, value := range puts {
+        batch.Set(key, value)
+    }
+    return batch.Write()
+}
+
+// Discard drops all staged mutations without touching the parent keyspace
+func (c *cacheKeyspace) Discard() {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    c.puts = make(map[string][]byte)
+    c.deletes = make(map[string]bool)
+}
+
+// cacheBatch stages its mutations into a cacheKeyspace's overlay instead of committing
+// them straight to storage
+type cacheBatch struct {
+    ks  *cacheKeyspace
+    ops []batchOp
+}
+
+// Set queues a key to be set to value when the batch is written
+func (batch *cacheBatch) Set(key string, value []byte) {
+    batch.ops = append(batch.ops, batchOp{key: key, value: value})
+}
+
+// Delete queues a key to be removed when the batch is written
+func (batch *cacheBatch) Delete(key string) {
+    batch.ops = append(batch.ops, batchOp{delete: true, key: key})
+}
+
+// Write stages all queued mutations into the overlay
+func (batch *cacheBatch) Write() error {
+    batch.ks.mu.Lock()
+    defer batch.ks.mu.Unlock()
+
+    for _, op := range batch.ops {
+        if op.delete {
+            delete(batch.ks.puts, op.key)
+            batch.ks.deletes[op.key] = true
+            continue
+        }
+        delete(batch.ks.deletes, op.key)
+        batch.ks.puts[op.key] = op.value
+    }
+    return nil
+}
+
+// Close discards the batch, releasing any queued mutations
+func (batch *cacheBatch) Close() {
+    batch.ops = nil
+}
+
+// cacheMergeIterator merges a staged overlay (puts, with deletes as tombstones) over a
+// parent Iterator, so Iterator/ReverseIterator/PrefixIterator reflect uncommitted
+// mutations without requiring them to be written first.
+type cacheMergeIterator struct {
+    parent  Iterator
+    overlay Iterator
+    deletes map[string]bool
+    reverse bool
+
+    key, value []byte
+    valid      bool
+}
+
+// less reports whether a sorts before b given the iteration direction
+func (it *cacheMergeIterator) less(a, b []byte) bool {
+    if it.reverse {
+        return bytes.Compare(a, b) > 0
+    }
+    return bytes.Compare(a, b) < 0
+}
+
+// advance positions the iterator at the next key not shadowed by a tombstone, preferring
+// the overlay's value whenever both the parent and overlay hold the same key.
+func (it *cacheMergeIterator) advance() {
+    for {
+        pValid, oValid := it.parent.Valid(), it.overlay.Valid()
+        if !pValid && !oValid {
+            it.valid = false
+            return
+        }
+
+        useOverlay := oValid && (!pValid || bytes.Equal(it.parent.Key(), it.overlay.Key()) || it.less(it.overlay.Key(), it.parent.Key()))
+
+        if useOverlay {
+            key, value := it.overlay.Key(), it.overlay.Value()
+            if pValid && bytes.Equal(it.parent.Key(), key) {
+                it.parent.Next()
+            }
+            it.overlay.Next()
+
+            it.key, it.value, it.valid = key, value, true
+            return
+        }
+
+        key, value := it.parent.Key(), it.parent.Value()
+        it.parent.Next()
+
+        if it.deletes[string(key)] {
+            continue
+        }
+        it.key, it.value, it.valid = key, value, true
+        return
+    }
+}
+
+// Valid returns whether the iterator is positioned at a valid key/value pair
+func (it *cacheMergeIterator) Valid() bool {
+    return it.valid
+}
+
+// Next advances the iterator to the next key/value pair
+func (it *cacheMergeIterator) Next() {
+    if !it.valid {
+        return
+    }
+    it.advance()
+}
+
+// Key returns the key at the current iterator position
+func (it *cacheMergeIterator) Key() []byte {
+    if !it.valid {
+        return nil
+    }
+    return it.key
+}
+
+// Value returns the value at the current iterator position
+func (it *cacheMergeIterator) Value() []byte {
+    if !it.valid {
+        return nil
+    }
+    return it.value
+}
+
+// Error returns any error encountered while iterating the parent keyspace
+func (it *cacheMergeIterator) Error() error {
+    return it.parent.Error()
+}
+
+// Close releases the parent iterator's underlying transaction
+func (it *cacheMergeIterator) Close() error {
+    return it.parent.Close()
+}
@@ -0,0 +1,222 @@
+package leaf
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestCacheWrapStagesWrites(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    err = ks.Insert("user1", []byte("parent"))
+    assert.Nil(t, err)
+
+    cache := ks.CacheWrap()
+
+    // staged write is visible through the overlay but not on the parent yet
+    err = cache.Insert("user2", []byte("staged"))
+    assert.Nil(t, err)
+
+    value, err := cache.Get("user2")
+    assert.Nil(t, err)
+    assert.Equal(t, []byte("staged"), value)
+
+    _, err = ks.Get("user2")
+    assert.Equal(t, ErrKeyNotFound, err)
+
+    // flushing the overlay applies the staged write to the parent
+    err = cache.Write()
+    assert.Nil(t, err)
+
+    value, err = ks.Get("user2")
+    assert.Nil(t, err)
+    assert.Equal(t, []byte("staged"), value)
+}
+
+func TestCacheWrapStagesDeletesAndTombstones(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    err = ks.Insert("user1", []byte("parent"))
+    assert.Nil(t, err)
+
+    cache := ks.CacheWrap()
+
+    err = cache.Delete("user1")
+    assert.Nil(t, err)
+
+    // the overlay reports the tombstone, but the parent is untouched
+    _, err = cache.Get("user1")
+    assert.Equal(t, ErrKeyNotFound, err)
+
+    value, err := ks.Get("user1")
+    assert.Nil(t, err)
+    assert.Equal(t, []byte("parent"), value)
+
+    err = cache.Write()
+    assert.Nil(t, err)
+
+    _, err = ks.Get("user1")
+    assert.Equal(t, ErrKeyNotFound, err)
+}
+
+func TestCacheWrapDiscard(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    cache := ks.CacheWrap()
+
+    err = cache.Insert("user1", []byte("staged"))
+    assert.Nil(t, err)
+
+    cache.Discard()
+
+    _, err = cache.Get("user1")
+    assert.Equal(t, ErrKeyNotFound, err)
+
+    _, err = ks.Get("user1")
+    assert.Equal(t, ErrKeyNotFound, err)
+}
+
+func TestCacheWrapIteratorMergesOverlay(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    assert.Nil(t, ks.Insert("user1", []byte("parent")))
+    assert.Nil(t, ks.Insert("user2", []byte("parent")))
+    assert.Nil(t, ks.Insert("user3", []byte("parent")))
+
+    cache := ks.CacheWrap()
+    assert.Nil(t, cache.Insert("user2", []byte("overridden")))
+    assert.Nil(t, cache.Delete("user3"))
+    assert.Nil(t, cache.Insert("user4", []byte("new")))
+
+    it, err := cache.Iterator(nil, nil)
+    assert.Nil(t, err)
+
+    var keys []string
+    values := make(map[string]string)
+    for ; it.Valid(); it.Next() {
+        keys = append(keys, string(it.Key()))
+        values[string(it.Key())] = string(it.Value())
+    }
+    assert.Nil(t, it.Close())
+
+    assert.Equal(t, []string{"user1", "user2", "user4"}, keys)
+    assert.Equal(t, "overridden", values["user2"])
+    assert.Equal(t, "new", values["user4"])
+}
+
+func TestCacheWrapRejectsStructuralChanges(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    child, err := ks.GetOrCreateChild("sessions")
+    assert.Nil(t, err)
+    assert.Nil(t, child.Insert("s1", []byte("active")))
+
+    cache := ks.CacheWrap()
+
+    // GetOrCreateChild/DeleteChild aren't staged by the overlay, so they must fail rather
+    // than mutate the parent ahead of Write/Discard
+    _, err = cache.GetOrCreateChild("sessions2")
+    assert.Equal(t, ErrCacheWrapStructuralChange, err)
+
+    err = cache.DeleteChild("sessions")
+    assert.Equal(t, ErrCacheWrapStructuralChange, err)
+
+    // the parent is untouched: no new child was created, and the existing one survives
+    found := make(map[string]bool)
+    err = ks.ForEachChild(func(name string, c Keyspace) error {
+        found[name] = true
+        return nil
+    })
+    assert.Nil(t, err)
+    assert.True(t, found["sessions"])
+    assert.False(t, found["sessions2"])
+
+    value, err := child.Get("s1")
+    assert.Nil(t, err)
+    assert.Equal(t, []byte("active"), value)
+}
+
+func TestCacheWrapForEachChildWrapsOverlay(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    child, err := ks.GetOrCreateChild("sessions")
+    assert.Nil(t, err)
+    assert.Nil(t, child.Insert("s1", []byte("parent")))
+
+    cache := ks.CacheWrap()
+
+    var childCache Keyspace
+    err = cache.ForEachChild(func(name string, c Keyspace) error {
+        if name == "sessions" {
+            childCache = c
+        }
+        return nil
+    })
+    assert.Nil(t, err)
+    assert.NotNil(t, childCache)
+
+    cacheChild, ok := childCache.(CacheWrap)
+    assert.True(t, ok)
+
+    // writes through the child's overlay stay staged until Write, same as any CacheWrap
+    assert.Nil(t, cacheChild.Insert("s1", []byte("staged")))
+    value, err := child.Get("s1")
+    assert.Nil(t, err)
+    assert.Equal(t, []byte("parent"), value)
+
+    assert.Nil(t, cacheChild.Write())
+    value, err = child.Get("s1")
+    assert.Nil(t, err)
+    assert.Equal(t, []byte("staged"), value)
+}
+
+func TestCacheWrapBatch(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    cache := ks.CacheWrap()
+
+    batch := cache.NewBatch()
+    batch.Set("user1", []byte("1"))
+    batch.Set("user2", []byte("2"))
+    assert.Nil(t, batch.Write())
+    batch.Close()
+
+    // staged in the overlay, not yet on the parent
+    _, err = ks.Get("user1")
+    assert.Equal(t, ErrKeyNotFound, err)
+
+    assert.Nil(t, cache.Write())
+
+    value, err := ks.Get("user1")
+    assert.Nil(t, err)
+    assert.Equal(t, []byte("1"), value)
+}
@@ -0,0 +1,115 @@
+package leaf
+
+import (
+    "bytes"
+    "encoding/gob"
+    "encoding/json"
+)
+
+// Codec marshals and unmarshals values stored in a CodecKeyspace, so callers don't have
+// to repeat the same encode/decode boilerplate at every call site.
+type Codec interface {
+    Marshal(v interface{}) ([]byte, error)
+    Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec encodes values with encoding/json
+type JSONCodec struct{}
+
+// Marshal encodes v as JSON
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+    return json.Marshal(v)
+}
+
+// Unmarshal decodes JSON data into v
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+    return json.Unmarshal(data, v)
+}
+
+// GobCodec encodes values with encoding/gob
+type GobCodec struct{}
+
+// Marshal encodes v with gob
+func (GobCodec) Marshal(v interface{}) ([]byte, error) {
+    var buf bytes.Buffer
+    if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+        return nil, err
+    }
+    return buf.Bytes(), nil
+}
+
+// Unmarshal decodes gob-encoded data into v
+func (GobCodec) Unmarshal(data []byte, v interface{}) error {
+    return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// CodecKeyspace layers a Codec on top of a Keyspace, so callers can Put/Get Go values of
+// type T directly instead of marshalling to []byte at every call site. The underlying
+// Keyspace is still reachable for raw []byte access and is left entirely untouched by
+// this wrapper.
+type CodecKeyspace[T any] struct {
+    Keyspace
+
+    codec Codec
+}
+
+// NewCodecKeyspace wraps ks with codec, storing and retrieving values of type T.
+func NewCodecKeyspace[T any](ks Keyspace, codec Codec) *CodecKeyspace[T] {
+    return &CodecKeyspace[T]{Keyspace: ks, codec: codec}
+}
+
+// Put marshals v with the configured Codec and inserts it under key
+func (c *CodecKeyspace[T]) Put(key string, v T) error {
+    data, err := c.codec.Marshal(v)
+    if err != nil {
+        return err
+    }
+    return c.Keyspace.Insert(key, data)
+}
+
+// Get fetches the value stored under key and unmarshals it into a T
+func (c *CodecKeyspace[T]) Get(key string) (T, error) {
+    var v T
+
+    data, err := c.Keyspace.Get(key)
+    if err != nil {
+        return v, err
+    }
+    if err := c.codec.Unmarshal(data, &v); err != nil {
+        return v, err
+    }
+    return v, nil
+}
+
+// ForEach iterates over the keyspace, unmarshalling each value before invoking each
+func (c *CodecKeyspace[T]) ForEach(each func(key string, v T) error) error {
+    return c.Keyspace.ForEach(func(k, raw []byte) error {
+        var v T
+        if err := c.codec.Unmarshal(raw, &v); err != nil {
+            return err
+        }
+        return each(string(k), v)
+    })
+}
+
+// List finds all the keys listed, unmarshalling each value before invoking each
+func (c *CodecKeyspace[T]) List(keys []string, each func(key string, v T) error) error {
+    var decodeErr error
+
+    err := c.Keyspace.List(keys, func(k, raw []byte) {
+        if decodeErr != nil {
+            return
+        }
+
+        var v T
+        if err := c.codec.Unmarshal(raw, &v); err != nil {
+            decodeErr = err
+            return
+        }
+        decodeErr = each(string(k), v)
+    })
+    if err != nil {
+        return err
+    }
+    return decodeErr
+}
@@ -0,0 +1,80 @@
+package leaf
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+type codecTestUser struct {
+    Name string
+    Age  int
+}
+
+func TestCodecKeyspaceJSONPutGet(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    typed := NewCodecKeyspace[codecTestUser](ks, JSONCodec{})
+
+    err = typed.Put("user1", codecTestUser{Name: "Ada", Age: 30})
+    assert.Nil(t, err)
+
+    // the raw bytes are still reachable through the embedded Keyspace
+    raw, err := ks.Get("user1")
+    assert.Nil(t, err)
+    assert.NotEmpty(t, raw)
+
+    got, err := typed.Get("user1")
+    assert.Nil(t, err)
+    assert.Equal(t, codecTestUser{Name: "Ada", Age: 30}, got)
+}
+
+func TestCodecKeyspaceGobForEach(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    typed := NewCodecKeyspace[codecTestUser](ks, GobCodec{})
+
+    assert.Nil(t, typed.Put("user1", codecTestUser{Name: "Ada", Age: 30}))
+    assert.Nil(t, typed.Put("user2", codecTestUser{Name: "Grace", Age: 41}))
+
+    found := make(map[string]codecTestUser)
+    err = typed.ForEach(func(key string, v codecTestUser) error {
+        found[key] = v
+        return nil
+    })
+    assert.Nil(t, err)
+
+    assert.Equal(t, codecTestUser{Name: "Ada", Age: 30}, found["user1"])
+    assert.Equal(t, codecTestUser{Name: "Grace", Age: 41}, found["user2"])
+}
+
+func TestCodecKeyspaceList(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    typed := NewCodecKeyspace[codecTestUser](ks, JSONCodec{})
+
+    assert.Nil(t, typed.Put("user1", codecTestUser{Name: "Ada", Age: 30}))
+    assert.Nil(t, typed.Put("user2", codecTestUser{Name: "Grace", Age: 41}))
+
+    found := make(map[string]codecTestUser)
+    err = typed.List([]string{"user1"}, func(key string, v codecTestUser) error {
+        found[key] = v
+        return nil
+    })
+    assert.Nil(t, err)
+
+    assert.Equal(t, 1, len(found))
+    assert.Equal(t, codecTestUser{Name: "Ada", Age: 30}, found["user1"])
+}
@@ -0,0 +1,198 @@
+package leaf
+
+import (
+    "bytes"
+
+    "github.com/boltdb/bolt"
+)
+
+// Iterator is a cursor-style abstraction for streaming a range of key/value pairs out
+// of a Keyspace without loading the whole range into memory, as ForEach does. The bolt
+// backend's Iterator wraps a *bolt.Cursor inside a long-lived read transaction, which
+// Close releases; the in-memory backend's Iterator walks a snapshot of the range taken
+// when it is created.
+type Iterator interface {
+
+    // Valid returns whether the iterator is positioned at a valid key/value pair
+    Valid() bool
+
+    // Next advances the iterator to the next key/value pair. It is a no-op once the
+    // iterator is no longer Valid.
+    Next()
+
+    // Key returns the key at the current iterator position
+    Key() []byte
+
+    // Value returns the value at the current iterator position
+    Value() []byte
+
+    // Error returns any error encountered while iterating
+    Error() error
+
+    // Close releases any read transaction backing the iterator
+    Close() error
+}
+
+// boltIterator implements Iterator on top of a *bolt.Cursor held open inside its own
+// read-only transaction.
+type boltIterator struct {
+    tx     *bolt.Tx
+    cursor *bolt.Cursor
+
+    start, end []byte
+    reverse    bool
+
+    key, value []byte
+    valid      bool
+    err        error
+}
+
+// newBoltIterator opens a read transaction on b's underlying database and positions a
+// cursor at the start of the range [start, end). An inverted range (start > end) yields
+// an immediately invalid iterator rather than an error, matching the Tendermint DB
+// iterator convention.
+func newBoltIterator(b *BoltKeyspace, start, end []byte, reverse bool) (Iterator, error) {
+    if start != nil && end != nil && bytes.Compare(start, end) > 0 {
+        return &boltIterator{start: start, end: end, reverse: reverse}, nil
+    }
+
+    tx, err := b.db.Begin(false)
+    if err != nil {
+        return nil, err
+    }
+
+    bkt, err := b.bucket(tx)
+    if err != nil {
+        tx.Rollback()
+        return nil, err
+    }
+
+    it := &boltIterator{tx: tx, cursor: bkt.Cursor(), start: start, end: end, reverse: reverse}
+    it.seek()
+    return it, nil
+}
+
+// seek positions the cursor at the first key of the range.
+func (it *boltIterator) seek() {
+    if it.reverse {
+        if it.end != nil {
+            it.key, it.value = it.cursor.Seek(it.end)
+            if it.key == nil {
+                // end is past the last key in the bucket
+                it.key, it.value = it.cursor.Last()
+            } else {
+                // end is exclusive, so step back past the key >= end that Seek landed on
+                it.key, it.value = it.cursor.Prev()
+            }
+        } else {
+            it.key, it.value = it.cursor.Last()
+        }
+        it.checkValid()
+        return
+    }
+
+    if it.start != nil {
+        it.key, it.value = it.cursor.Seek(it.start)
+    } else {
+        it.key, it.value = it.cursor.First()
+    }
+    it.checkValid()
+}
+
+// checkValid updates it.valid based on the current key and the [start, end) bounds.
+func (it *boltIterator) checkValid() {
+    if it.key == nil {
+        it.valid = false
+        return
+    }
+
+    if it.reverse {
+        it.valid = it.start == nil || bytes.Compare(it.key, it.start) >= 0
+        return
+    }
+    it.valid = it.end == nil || bytes.Compare(it.key, it.end) < 0
+}
+
+// Valid returns whether the iterator is positioned at a valid key/value pair
+func (it *boltIterator) Valid() bool {
+    return it.valid
+}
+
+// Next advances the iterator to the next key/value pair
+func (it *boltIterator) Next() {
+    if !it.valid || it.cursor == nil {
+        return
+    }
+
+    if it.reverse {
+        it.key, it.value = it.cursor.Prev()
+    } else {
+        it.key, it.value = it.cursor.Next()
+    }
+    it.checkValid()
+}
+
+// Key returns the key at the current iterator position
+func (it *boltIterator) Key() []byte {
+    if !it.valid {
+        return nil
+    }
+    return it.key
+}
+
+// Value returns the value at the current iterator position
+func (it *boltIterator) Value() []byte {
+    if !it.valid {
+        return nil
+    }
+    return it.value
+}
+
+// Error returns any error encountered while iterating
+func (it *boltIterator) Error() error {
+    return it.err
+}
+
+// Close releases the read transaction backing the iterator
+func (it *boltIterator) Close() error {
+    if it.tx == nil {
+        return nil
+    }
+    return it.tx.Rollback()
+}
+
+// prefixRangeEnd returns the smallest key greater than every key with the given prefix,
+// for use as the exclusive end of a prefix range. It returns nil if prefix is empty or
+// consists entirely of 0xff bytes, meaning the range is unbounded above.
+func prefixRangeEnd(prefix []byte) []byte {
+    if len(prefix) == 0 {
+        return nil
+    }
+
+    end := make([]byte, len(prefix))
+    copy(end, prefix)
+
+    for i := len(end) - 1; i >= 0; i-- {
+        if end[i] < 0xff {
+            end[i]++
+            return end[:i+1]
+        }
+    }
+    return nil
+}
+
+// Iterator returns an Iterator over the range [start, end) of the keyspace
+func (b *BoltKeyspace) Iterator(start, end []byte) (Iterator, error) {
+    return newBoltIterator(b, start, end, false)
+}
+
+// ReverseIterator returns an Iterator over the range [start, end) of the keyspace,
+// moving from the last matching key to the first
+func (b *BoltKeyspace) ReverseIterator(start, end []byte) (Iterator, error) {
+    return newBoltIterator(b, start, end, true)
+}
+
+// PrefixIterator returns an Iterator over every key in the keyspace beginning with prefix
+func (b *BoltKeyspace) PrefixIterator(prefix []byte) (Iterator, error) {
+    return b.Iterator(prefix, prefixRangeEnd(prefix))
+}
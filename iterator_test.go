@@ -0,0 +1,172 @@
+package leaf
+
+import (
+    "os"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestBoltKeyspaceIterator(t *testing.T) {
+    file := os.TempDir() + "/leaf.db"
+
+    leaf, err := NewLeaf(file)
+    defer leaf.Close()
+
+    assert.Equal(t, nil, err)
+    assert.NotEqual(t, nil, leaf)
+
+    ks, err := leaf.GetOrCreateKeyspace("users")
+    assert.Equal(t, nil, err)
+    assert.NotEqual(t, nil, ks)
+
+    for i, key := range []string{"user1", "user2", "user3", "user4"} {
+        err = ks.Insert(key, []byte{byte(i)})
+        assert.Nil(t, err)
+    }
+
+    // [user2, user4) -- user2 inclusive, user4 exclusive
+    it, err := ks.Iterator([]byte("user2"), []byte("user4"))
+    assert.Nil(t, err)
+
+    var keys []string
+    for ; it.Valid(); it.Next() {
+        keys = append(keys, string(it.Key()))
+    }
+    assert.Nil(t, it.Close())
+
+    assert.Equal(t, []string{"user2", "user3"}, keys)
+
+    leaf.DeleteKeyspace("users")
+}
+
+func TestBoltKeyspaceIteratorUnbounded(t *testing.T) {
+    file := os.TempDir() + "/leaf.db"
+
+    leaf, err := NewLeaf(file)
+    defer leaf.Close()
+
+    assert.Equal(t, nil, err)
+
+    ks, err := leaf.GetOrCreateKeyspace("users")
+    assert.Equal(t, nil, err)
+
+    for _, key := range []string{"user1", "user2", "user3"} {
+        err = ks.Insert(key, []byte("1"))
+        assert.Nil(t, err)
+    }
+
+    it, err := ks.Iterator(nil, nil)
+    assert.Nil(t, err)
+
+    var keys []string
+    for ; it.Valid(); it.Next() {
+        keys = append(keys, string(it.Key()))
+    }
+    assert.Nil(t, it.Close())
+
+    assert.Equal(t, []string{"user1", "user2", "user3"}, keys)
+
+    leaf.DeleteKeyspace("users")
+}
+
+func TestBoltKeyspaceReverseIterator(t *testing.T) {
+    file := os.TempDir() + "/leaf.db"
+
+    leaf, err := NewLeaf(file)
+    defer leaf.Close()
+
+    assert.Equal(t, nil, err)
+
+    ks, err := leaf.GetOrCreateKeyspace("users")
+    assert.Equal(t, nil, err)
+
+    for _, key := range []string{"user1", "user2", "user3", "user4"} {
+        err = ks.Insert(key, []byte("1"))
+        assert.Nil(t, err)
+    }
+
+    it, err := ks.ReverseIterator([]byte("user2"), []byte("user4"))
+    assert.Nil(t, err)
+
+    var keys []string
+    for ; it.Valid(); it.Next() {
+        keys = append(keys, string(it.Key()))
+    }
+    assert.Nil(t, it.Close())
+
+    assert.Equal(t, []string{"user3", "user2"}, keys)
+
+    leaf.DeleteKeyspace("users")
+}
+
+func TestBoltKeyspacePrefixIterator(t *testing.T) {
+    file := os.TempDir() + "/leaf.db"
+
+    leaf, err := NewLeaf(file)
+    defer leaf.Close()
+
+    assert.Equal(t, nil, err)
+
+    ks, err := leaf.GetOrCreateKeyspace("users")
+    assert.Equal(t, nil, err)
+
+    for _, key := range []string{"user/1", "user/2", "group/1"} {
+        err = ks.Insert(key, []byte("1"))
+        assert.Nil(t, err)
+    }
+
+    it, err := ks.PrefixIterator([]byte("user/"))
+    assert.Nil(t, err)
+
+    var keys []string
+    for ; it.Valid(); it.Next() {
+        keys = append(keys, string(it.Key()))
+    }
+    assert.Nil(t, it.Close())
+
+    assert.Equal(t, []string{"user/1", "user/2"}, keys)
+
+    leaf.DeleteKeyspace("users")
+}
+
+func TestBoltKeyspaceIteratorInvertedRange(t *testing.T) {
+    file := os.TempDir() + "/leaf.db"
+
+    leaf, err := NewLeaf(file)
+    defer leaf.Close()
+
+    assert.Equal(t, nil, err)
+
+    ks, err := leaf.GetOrCreateKeyspace("users")
+    assert.Equal(t, nil, err)
+
+    err = ks.Insert("user1", []byte("1"))
+    assert.Nil(t, err)
+
+    it, err := ks.Iterator([]byte("user9"), []byte("user1"))
+    assert.Nil(t, err)
+    assert.False(t, it.Valid())
+    assert.Nil(t, it.Close())
+
+    leaf.DeleteKeyspace("users")
+}
+
+func TestBoltKeyspaceIteratorEmptyBucket(t *testing.T) {
+    file := os.TempDir() + "/leaf.db"
+
+    leaf, err := NewLeaf(file)
+    defer leaf.Close()
+
+    assert.Equal(t, nil, err)
+
+    ks, err := leaf.GetOrCreateKeyspace("empty")
+    assert.Equal(t, nil, err)
+
+    it, err := ks.Iterator(nil, nil)
+    assert.Nil(t, err)
+    assert.False(t, it.Valid())
+    assert.Nil(t, it.Close())
+
+    leaf.DeleteKeyspace("empty")
+}
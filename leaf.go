@@ -4,6 +4,7 @@ import (
     "bytes"
     "errors"
     "sort"
+    "strings"
 
     "github.com/boltdb/bolt"
 )
@@ -17,10 +18,10 @@ var (
 
     // ErrEmptyKeyList is returned if Keyspace.List() is called with no keys
     ErrEmptyKeyList = errors.New("Empty key list")
-)
 
-// TxCallback allows for more complex operations on a bucket. It is utilized in the ReadTx and WriteTx functions.
-// type TxCallback func(*bolt.Bucket)
+    // ErrChildNotFound is returned if a child keyspace does not exist
+    ErrChildNotFound = errors.New("Child keyspace does not exist")
+)
 
 // Keyspace is an interface for Database keyspaces. It is used as a wrapper for database actions.
 type Keyspace interface {
@@ -52,11 +53,46 @@ type Keyspace interface {
     // Contains determines if the given key exists in the keyspace
     Contains(string) (bool, error)
 
-    // ReadTx allows for more complicated read operations on a particular key, such as reading nested values.
-    ReadTx(func(*bolt.Bucket)) error
+    // ReadTx allows for more complicated read operations on a particular key, such as reading nested values,
+    // through a backend-neutral Tx rather than a concrete storage type.
+    ReadTx(func(Tx) error) error
+
+    // WriteTx allows for more complicated write operations on a particular key, such as writing nested values,
+    // through a backend-neutral Tx rather than a concrete storage type.
+    WriteTx(func(Tx) error) error
+
+    // GetOrCreateChild returns a nested keyspace scoped under this one, creating it if it doesn't exist
+    GetOrCreateChild(name string) (Keyspace, error)
+
+    // DeleteChild removes a nested keyspace from this one
+    DeleteChild(name string) error
+
+    // ForEachChild iterates over all the direct child keyspaces nested under this one
+    ForEachChild(func(name string, ks Keyspace) error) error
 
-    // WriteTx allows for more complicated write operations on a particular key, such as writing nested values.
-    WriteTx(func(*bolt.Bucket)) error
+    // Iterator returns an Iterator over the range [start, end) of the keyspace, with start
+    // inclusive and end exclusive. A nil start iterates from the first key and a nil end
+    // iterates through the last key.
+    Iterator(start, end []byte) (Iterator, error)
+
+    // ReverseIterator returns an Iterator over the range [start, end) of the keyspace, with
+    // start inclusive and end exclusive, moving from the last matching key to the first.
+    ReverseIterator(start, end []byte) (Iterator, error)
+
+    // PrefixIterator returns an Iterator over every key in the keyspace beginning with prefix.
+    PrefixIterator(prefix []byte) (Iterator, error)
+
+    // NewBatch returns a Batch that queues mutations to be committed atomically
+    NewBatch() Batch
+
+    // CacheWrap returns an in-memory overlay over this keyspace that buffers mutations
+    // until Write is called, or discards them via Discard.
+    CacheWrap() CacheWrap
+
+    // Watch subscribes to every subsequent Put/Delete whose key matches prefix, returning
+    // a channel of Events and a CancelFunc that unsubscribes and closes the channel.
+    // Mutations made through WriteTx are not reported.
+    Watch(prefix string) (<-chan Event, CancelFunc)
 }
 
 // KeyValueDatabase is used as an interface for accessing multiple keyspaces.
@@ -72,18 +108,26 @@ type KeyValueDatabase interface {
     Close() error
 }
 
-// NewLeaf creates a connection to a BoltDB file
+func init() {
+    RegisterBackend("bolt", func(opts Options) (KeyValueDatabase, error) {
+        return NewLeaf(opts.Path)
+    })
+}
+
+// NewLeaf creates a connection to a BoltDB file. The bolt backend is also registered
+// under the name "bolt" for use with OpenBackend.
 func NewLeaf(file string) (KeyValueDatabase, error) {
     db, err := bolt.Open(file, 0755, nil)
     if err != nil {
         return nil, err
     }
-    return &DB{db}, nil
+    return &DB{db: db, hubs: newHubRegistry()}, nil
 }
 
 // DB wraps a BoltDB connection
 type DB struct {
-    db *bolt.DB
+    db   *bolt.DB
+    hubs *hubRegistry
 }
 
 // GetOrCreateKeyspace returns a Keyspace implementation for the underlying BoltDB instance.
@@ -91,7 +135,7 @@ func (l *DB) GetOrCreateKeyspace(name string) (ks Keyspace, err error) {
     err = l.db.Update(func(tx *bolt.Tx) error {
         _, er := tx.CreateBucketIfNotExists([]byte(name))
 
-        ks = &BoltKeyspace{name, l.db}
+        ks = &BoltKeyspace{name: name, db: l.db, path: []string{name}, hubs: l.hubs}
         return er
     })
     return ks, err
@@ -107,13 +151,53 @@ func (l *DB) DeleteKeyspace(name string) error {
     err := l.db.Update(func(tx *bolt.Tx) error {
         return tx.DeleteBucket([]byte(name))
     })
-    return err
+    if err != nil {
+        return err
+    }
+    l.hubs.closeAndDelete(name)
+    return nil
 }
 
 // BoltKeyspace implements the Keyspace interface on top of a boltdb connection
 type BoltKeyspace struct {
     name string
     db   *bolt.DB
+
+    // path is the chain of bucket names from the root of the database down to
+    // this keyspace, so nested keyspaces can resolve their bucket on every
+    // transaction without holding a reference to a bolt.Bucket across transactions.
+    path []string
+
+    // hubs is shared with every BoltKeyspace derived from the same DB, so Watch
+    // subscribers see mutations regardless of which instance applied them.
+    hubs *hubRegistry
+}
+
+// hub returns the watchHub for this keyspace's path
+func (b *BoltKeyspace) hub() *watchHub {
+    return b.hubs.get(strings.Join(b.path, "/"))
+}
+
+// Watch subscribes to every subsequent Put/Delete whose key matches prefix
+func (b *BoltKeyspace) Watch(prefix string) (<-chan Event, CancelFunc) {
+    return b.hub().Watch(prefix)
+}
+
+// bucket walks the path from the root of the transaction down to the bucket backing
+// this keyspace, returning ErrChildNotFound if any segment no longer exists.
+func (b *BoltKeyspace) bucket(tx *bolt.Tx) (*bolt.Bucket, error) {
+    bkt := tx.Bucket([]byte(b.path[0]))
+    if bkt == nil {
+        return nil, ErrChildNotFound
+    }
+
+    for _, name := range b.path[1:] {
+        bkt = bkt.Bucket([]byte(name))
+        if bkt == nil {
+            return nil, ErrChildNotFound
+        }
+    }
+    return bkt, nil
 }
 
 // GetName returns the name of the keyspace
@@ -141,10 +225,13 @@ func (b *BoltKeyspace) List(keys []string, callback func(k, v []byte)) error {
     err := b.db.View(func(tx *bolt.Tx) error {
 
         // open bucket
-        b := tx.Bucket([]byte(b.name))
+        bkt, err := b.bucket(tx)
+        if err != nil {
+            return err
+        }
 
         // create cursor
-        c := b.Cursor()
+        c := bkt.Cursor()
 
         // iterate over bucket keys from first key to last
         last := []byte(keys[len(keys)-1])
@@ -166,20 +253,36 @@ func (b *BoltKeyspace) List(keys []string, callback func(k, v []byte)) error {
 // Insert adds a key value pair to the databaes
 func (b *BoltKeyspace) Insert(key string, value []byte) error {
 
+    hub := b.hub()
+    hub.writeMu.Lock()
+    defer hub.writeMu.Unlock()
+
+    var prev []byte
     err := b.db.Update(func(tx *bolt.Tx) error {
-        b := tx.Bucket([]byte(b.name))
-        err := b.Put([]byte(key), value)
-        return err
+        bkt, err := b.bucket(tx)
+        if err != nil {
+            return err
+        }
+        prev = copyBytes(bkt.Get([]byte(key)))
+        return bkt.Put([]byte(key), value)
     })
-    return err
+    if err != nil {
+        return err
+    }
+
+    hub.emit(Event{Type: EventPut, Key: []byte(key), Value: value, PrevValue: prev})
+    return nil
 }
 
 // Get returns the value for the given key
 func (b *BoltKeyspace) Get(key string) (value []byte, err error) {
 
     err = b.db.View(func(tx *bolt.Tx) error {
-        b := tx.Bucket([]byte(b.name))
-        value = b.Get([]byte(key))
+        bkt, err := b.bucket(tx)
+        if err != nil {
+            return err
+        }
+        value = bkt.Get([]byte(key))
         if value == nil {
             return ErrKeyNotFound
         }
@@ -195,17 +298,46 @@ func (b *BoltKeyspace) Update(key string, value []byte) error {
 
 // Delete removes a key from the keyspace
 func (b *BoltKeyspace) Delete(key string) error {
-    return b.db.Update(func(tx *bolt.Tx) error {
-        b := tx.Bucket([]byte(b.name))
-        return b.Delete([]byte(key))
+    hub := b.hub()
+    hub.writeMu.Lock()
+    defer hub.writeMu.Unlock()
+
+    var prev []byte
+    err := b.db.Update(func(tx *bolt.Tx) error {
+        bkt, err := b.bucket(tx)
+        if err != nil {
+            return err
+        }
+        prev = copyBytes(bkt.Get([]byte(key)))
+        return bkt.Delete([]byte(key))
     })
+    if err != nil {
+        return err
+    }
+
+    hub.emit(Event{Type: EventDelete, Key: []byte(key), PrevValue: prev})
+    return nil
+}
+
+// copyBytes returns a copy of b, since values read inside a bolt transaction are only
+// valid until the transaction ends
+func copyBytes(b []byte) []byte {
+    if b == nil {
+        return nil
+    }
+    cp := make([]byte, len(b))
+    copy(cp, b)
+    return cp
 }
 
 // Size returns the number of keys in the keyspace
 func (b *BoltKeyspace) Size() (value int64) {
     b.db.View(func(tx *bolt.Tx) error {
-        bucket := tx.Bucket([]byte(b.name))
-        stats := bucket.Stats()
+        bkt, err := b.bucket(tx)
+        if err != nil {
+            return err
+        }
+        stats := bkt.Stats()
         value = int64(stats.KeyN)
         return nil
     })
@@ -215,8 +347,11 @@ func (b *BoltKeyspace) Size() (value int64) {
 // ForEach iterates over all the key value pairs in the keyspace
 func (b *BoltKeyspace) ForEach(each ItemHandler) error {
     return b.db.View(func(tx *bolt.Tx) error {
-        b := tx.Bucket([]byte(b.name))
-        return b.ForEach(each)
+        bkt, err := b.bucket(tx)
+        if err != nil {
+            return err
+        }
+        return bkt.ForEach(each)
     })
 }
 
@@ -224,8 +359,11 @@ func (b *BoltKeyspace) ForEach(each ItemHandler) error {
 func (b *BoltKeyspace) Contains(key string) (exists bool, err error) {
 
     err = b.db.View(func(tx *bolt.Tx) error {
-        b := tx.Bucket([]byte(b.name))
-        value := b.Get([]byte(key))
+        bkt, err := b.bucket(tx)
+        if err != nil {
+            return err
+        }
+        value := bkt.Get([]byte(key))
         if value != nil {
             exists = true
         }
@@ -235,24 +373,92 @@ func (b *BoltKeyspace) Contains(key string) (exists bool, err error) {
     return exists, err
 }
 
-// ReadTx allows for more complex read operations on the keyspace
-func (b *BoltKeyspace) ReadTx(callback func(*bolt.Bucket)) error {
-    err := b.db.View(func(tx *bolt.Tx) error {
-        bkt := tx.Bucket([]byte(b.name))
+// ReadTx allows for more complex read operations on the keyspace through a backend-neutral Tx
+func (b *BoltKeyspace) ReadTx(callback func(Tx) error) error {
+    return b.db.View(func(tx *bolt.Tx) error {
+        bkt, err := b.bucket(tx)
+        if err != nil {
+            return err
+        }
+
+        return callback(&boltTx{bkt})
+    })
+}
+
+// WriteTx allows for more complex write operations on the keyspace through a backend-neutral Tx
+func (b *BoltKeyspace) WriteTx(callback func(Tx) error) error {
+    return b.db.Update(func(tx *bolt.Tx) error {
+        bkt, err := b.bucket(tx)
+        if err != nil {
+            return err
+        }
+
+        return callback(&boltTx{bkt})
+    })
+}
 
-        callback(bkt)
+// GetOrCreateChild returns a nested keyspace scoped under this one, creating the
+// underlying bucket if it doesn't already exist. The child participates in the
+// same transaction machinery as its parent, resolving its full bucket path on
+// every Get/Insert/Delete.
+func (b *BoltKeyspace) GetOrCreateChild(name string) (ks Keyspace, err error) {
+    err = b.db.Update(func(tx *bolt.Tx) error {
+        bkt, err := b.bucket(tx)
+        if err != nil {
+            return err
+        }
+
+        _, err = bkt.CreateBucketIfNotExists([]byte(name))
+        if err != nil {
+            return err
+        }
+
+        path := make([]string, len(b.path)+1)
+        copy(path, b.path)
+        path[len(b.path)] = name
+
+        ks = &BoltKeyspace{name: name, db: b.db, path: path, hubs: b.hubs}
         return nil
     })
-    return err
+    return ks, err
 }
 
-// WriteTx allows for more complex write operations on the keyspace
-func (b *BoltKeyspace) WriteTx(callback func(*bolt.Bucket)) error {
+// DeleteChild removes a nested keyspace from this one
+func (b *BoltKeyspace) DeleteChild(name string) error {
     err := b.db.Update(func(tx *bolt.Tx) error {
-        bkt := tx.Bucket([]byte(b.name))
+        bkt, err := b.bucket(tx)
+        if err != nil {
+            return err
+        }
+        return bkt.DeleteBucket([]byte(name))
+    })
+    if err != nil {
+        return err
+    }
 
-        callback(bkt)
-        return nil
+    b.hubs.closeAndDelete(strings.Join(b.path, "/") + "/" + name)
+    return nil
+}
+
+// ForEachChild iterates over all the direct child keyspaces nested under this one
+func (b *BoltKeyspace) ForEachChild(each func(name string, ks Keyspace) error) error {
+    return b.db.View(func(tx *bolt.Tx) error {
+        bkt, err := b.bucket(tx)
+        if err != nil {
+            return err
+        }
+
+        return bkt.ForEach(func(k, v []byte) error {
+            // nested buckets are reported with a nil value by bolt's cursor
+            if v != nil {
+                return nil
+            }
+
+            path := make([]string, len(b.path)+1)
+            copy(path, b.path)
+            path[len(b.path)] = string(k)
+
+            return each(string(k), &BoltKeyspace{name: string(k), db: b.db, path: path, hubs: b.hubs})
+        })
     })
-    return err
 }
@@ -308,6 +308,124 @@ func TestBoltKeyspaceForEach(t *testing.T) {
     leaf.DeleteKeyspace("users")
 }
 
+func TestBoltKeyspaceGetOrCreateChild(t *testing.T) {
+    file := os.TempDir() + "/leaf.db"
+
+    leaf, err := NewLeaf(file)
+    defer leaf.Close()
+
+    // created db
+    assert.Equal(t, nil, err)
+    assert.NotEqual(t, nil, leaf)
+
+    // create keyspace
+    ks, err := leaf.GetOrCreateKeyspace("users")
+    assert.Equal(t, nil, err)
+    assert.NotEqual(t, nil, ks)
+
+    // create child keyspace
+    child, err := ks.GetOrCreateChild("sessions")
+    assert.Nil(t, err)
+    assert.NotNil(t, child)
+    assert.Equal(t, "sessions", child.GetName())
+
+    // child participates in the same transaction machinery as its parent
+    err = child.Insert("session1", []byte("active"))
+    assert.Nil(t, err)
+
+    value, err := child.Get("session1")
+    assert.Nil(t, err)
+    assert.Equal(t, []byte("active"), value)
+
+    // fetching it again resolves the same nested bucket
+    again, err := ks.GetOrCreateChild("sessions")
+    assert.Nil(t, err)
+
+    value, err = again.Get("session1")
+    assert.Nil(t, err)
+    assert.Equal(t, []byte("active"), value)
+
+    leaf.DeleteKeyspace("users")
+}
+
+func TestBoltKeyspaceDeleteChild(t *testing.T) {
+    file := os.TempDir() + "/leaf.db"
+
+    leaf, err := NewLeaf(file)
+    defer leaf.Close()
+
+    // created db
+    assert.Equal(t, nil, err)
+    assert.NotEqual(t, nil, leaf)
+
+    // create keyspace
+    ks, err := leaf.GetOrCreateKeyspace("users")
+    assert.Equal(t, nil, err)
+    assert.NotEqual(t, nil, ks)
+
+    // create child keyspace
+    old, err := ks.GetOrCreateChild("sessions")
+    assert.Nil(t, err)
+    assert.Nil(t, old.Insert("session1", []byte("active")))
+
+    // delete it
+    err = ks.DeleteChild("sessions")
+    assert.Nil(t, err)
+
+    // child no longer resolves
+    _, err = old.Get("session1")
+    assert.Equal(t, ErrChildNotFound, err)
+
+    // recreating it yields an empty keyspace
+    child, err := ks.GetOrCreateChild("sessions")
+    assert.Nil(t, err)
+    _, err = child.Get("session1")
+    assert.Equal(t, ErrKeyNotFound, err)
+
+    leaf.DeleteKeyspace("users")
+}
+
+func TestBoltKeyspaceForEachChild(t *testing.T) {
+    file := os.TempDir() + "/leaf.db"
+
+    leaf, err := NewLeaf(file)
+    defer leaf.Close()
+
+    // created db
+    assert.Equal(t, nil, err)
+    assert.NotEqual(t, nil, leaf)
+
+    // create keyspace
+    ks, err := leaf.GetOrCreateKeyspace("users")
+    assert.Equal(t, nil, err)
+    assert.NotEqual(t, nil, ks)
+
+    // create children
+    _, err = ks.GetOrCreateChild("sessions")
+    assert.Nil(t, err)
+
+    _, err = ks.GetOrCreateChild("profile")
+    assert.Nil(t, err)
+
+    // also insert a regular key, which should not be reported as a child
+    err = ks.Insert("user1", []byte("1"))
+    assert.Nil(t, err)
+
+    found := make(map[string]bool)
+    err = ks.ForEachChild(func(name string, child Keyspace) error {
+        found[name] = true
+        assert.Equal(t, name, child.GetName())
+        return nil
+    })
+    assert.Nil(t, err)
+
+    assert.True(t, found["sessions"])
+    assert.True(t, found["profile"])
+    assert.False(t, found["user1"])
+
+    leaf.DeleteKeyspace("users")
+}
+
 func TestBoltKeyspaceContains(t *testing.T) {
     file := os.TempDir() + "/leaf.db"
 
@@ -336,3 +454,62 @@ func TestBoltKeyspaceContains(t *testing.T) {
 
     leaf.DeleteKeyspace("users")
 }
+
+func TestBoltKeyspaceReadWriteTx(t *testing.T) {
+    file := os.TempDir() + "/leaf.db"
+
+    leaf, err := NewLeaf(file)
+    defer leaf.Close()
+
+    // created db
+    assert.Equal(t, nil, err)
+    assert.NotEqual(t, nil, leaf)
+
+    // create keyspace
+    ks, err := leaf.GetOrCreateKeyspace("users")
+    assert.Equal(t, nil, err)
+    assert.NotEqual(t, nil, ks)
+
+    // put through a WriteTx
+    err = ks.WriteTx(func(tx Tx) error {
+        if err := tx.Put([]byte("user1"), []byte("Ada")); err != nil {
+            return err
+        }
+        return tx.Put([]byte("user2"), []byte("Grace"))
+    })
+    assert.Nil(t, err)
+
+    // get and cursor through a ReadTx
+    err = ks.ReadTx(func(tx Tx) error {
+        value, err := tx.Get([]byte("user1"))
+        assert.Nil(t, err)
+        assert.Equal(t, []byte("Ada"), value)
+
+        cursor := tx.Cursor()
+        k, v := cursor.First()
+        assert.Equal(t, []byte("user1"), k)
+        assert.Equal(t, []byte("Ada"), v)
+
+        k, v = cursor.Next()
+        assert.Equal(t, []byte("user2"), k)
+        assert.Equal(t, []byte("Grace"), v)
+
+        return nil
+    })
+    assert.Nil(t, err)
+
+    // delete through a WriteTx
+    err = ks.WriteTx(func(tx Tx) error {
+        return tx.Delete([]byte("user1"))
+    })
+    assert.Nil(t, err)
+
+    err = ks.ReadTx(func(tx Tx) error {
+        _, err := tx.Get([]byte("user1"))
+        assert.Equal(t, ErrKeyNotFound, err)
+        return nil
+    })
+    assert.Nil(t, err)
+
+    leaf.DeleteKeyspace("users")
+}
@@ -0,0 +1,594 @@
+package leaf
+
+import (
+    "bytes"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+)
+
+func init() {
+    RegisterBackend("memory", func(opts Options) (KeyValueDatabase, error) {
+        return NewMemoryDB(), nil
+    })
+}
+
+// NewMemoryDB creates an in-memory KeyValueDatabase. It holds every keyspace in a single
+// map guarded by an RWMutex rather than touching disk, which makes it useful for tests
+// and for write-heavy workloads that don't need bolt's durability guarantees.
+func NewMemoryDB() KeyValueDatabase {
+    return &memoryDB{data: make(map[string]map[string][]byte), hubs: newHubRegistry()}
+}
+
+// memoryDB is the in-memory KeyValueDatabase implementation. Nested keyspaces are stored
+// as additional entries keyed by their path, built by concatenating each segment's
+// encodeSegment encoding. Encoding every segment with its own length prefix, rather than
+// joining raw names with "/", means a keyspace or child named e.g. "users/sessions" can
+// never collide with the genuinely nested child "sessions" under "users".
+type memoryDB struct {
+    mu   sync.RWMutex
+    data map[string]map[string][]byte
+    hubs *hubRegistry
+}
+
+// encodeSegment encodes a single path segment as "<len>:<name>", a netstring-style
+// length prefix. Because the prefix records name's exact byte length, concatenating
+// encoded segments can never be ambiguous regardless of what characters name contains.
+func encodeSegment(name string) string {
+    return strconv.Itoa(len(name)) + ":" + name
+}
+
+// decodeSegment reads a single encodeSegment-encoded segment from the front of s,
+// returning the decoded name and the remaining, still-encoded suffix.
+func decodeSegment(s string) (name, rest string, ok bool) {
+    i := strings.IndexByte(s, ':')
+    if i < 0 {
+        return "", "", false
+    }
+    n, err := strconv.Atoi(s[:i])
+    if err != nil || n < 0 || i+1+n > len(s) {
+        return "", "", false
+    }
+    return s[i+1 : i+1+n], s[i+1+n:], true
+}
+
+// GetOrCreateKeyspace returns a Keyspace backed by this memoryDB, creating it if it doesn't exist
+func (m *memoryDB) GetOrCreateKeyspace(name string) (Keyspace, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    path := encodeSegment(name)
+    if _, ok := m.data[path]; !ok {
+        m.data[path] = make(map[string][]byte)
+    }
+    return &memoryKeyspace{name: name, path: path, db: m}, nil
+}
+
+// DeleteKeyspace removes a keyspace, and any of its nested children, from the database
+func (m *memoryDB) DeleteKeyspace(name string) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    path := encodeSegment(name)
+    for p := range m.data {
+        if p == path || strings.HasPrefix(p, path) {
+            delete(m.data, p)
+            m.hubs.closeAndDelete(p)
+        }
+    }
+
+    m.hubs.closeAndDelete(path)
+    return nil
+}
+
+// Close is a no-op for the in-memory backend
+func (m *memoryDB) Close() error {
+    return nil
+}
+
+// memoryKeyspace implements Keyspace on top of a memoryDB bucket
+type memoryKeyspace struct {
+    name string
+    path string
+    db   *memoryDB
+}
+
+// bucket returns the map backing this keyspace, or ErrChildNotFound if it no longer exists
+func (k *memoryKeyspace) bucket() (map[string][]byte, error) {
+    bkt, ok := k.db.data[k.path]
+    if !ok {
+        return nil, ErrChildNotFound
+    }
+    return bkt, nil
+}
+
+// GetName returns the name of the keyspace
+func (k *memoryKeyspace) GetName() string {
+    return k.name
+}
+
+// List finds all the keys listed and calls the function provided with the key value pairs
+func (k *memoryKeyspace) List(keys []string, callback func(k, v []byte)) error {
+    if len(keys) == 0 {
+        return ErrEmptyKeyList
+    }
+
+    k.db.mu.RLock()
+    defer k.db.mu.RUnlock()
+
+    bkt, err := k.bucket()
+    if err != nil {
+        return err
+    }
+
+    for _, key := range keys {
+        if value, ok := bkt[key]; ok {
+            callback([]byte(key), value)
+        }
+    }
+    return nil
+}
+
+// Insert adds a key value pair to the keyspace
+func (k *memoryKeyspace) Insert(key string, value []byte) error {
+    k.db.mu.Lock()
+    defer k.db.mu.Unlock()
+
+    bkt, err := k.bucket()
+    if err != nil {
+        return err
+    }
+
+    prev := bkt[key]
+    bkt[key] = value
+
+    k.hub().emit(Event{Type: EventPut, Key: []byte(key), Value: value, PrevValue: prev})
+    return nil
+}
+
+// Get returns the value for the given key
+func (k *memoryKeyspace) Get(key string) ([]byte, error) {
+    k.db.mu.RLock()
+    defer k.db.mu.RUnlock()
+
+    bkt, err := k.bucket()
+    if err != nil {
+        return nil, err
+    }
+
+    value, ok := bkt[key]
+    if !ok {
+        return nil, ErrKeyNotFound
+    }
+    return value, nil
+}
+
+// Update overwrites an existing value
+func (k *memoryKeyspace) Update(key string, value []byte) error {
+    return k.Insert(key, value)
+}
+
+// Delete removes a key from the keyspace
+func (k *memoryKeyspace) Delete(key string) error {
+    k.db.mu.Lock()
+    defer k.db.mu.Unlock()
+
+    bkt, err := k.bucket()
+    if err != nil {
+        return err
+    }
+
+    prev := bkt[key]
+    delete(bkt, key)
+
+    k.hub().emit(Event{Type: EventDelete, Key: []byte(key), PrevValue: prev})
+    return nil
+}
+
+// hub returns the watchHub for this keyspace's path
+func (k *memoryKeyspace) hub() *watchHub {
+    return k.db.hubs.get(k.path)
+}
+
+// Watch subscribes to every subsequent Put/Delete whose key matches prefix
+func (k *memoryKeyspace) Watch(prefix string) (<-chan Event, CancelFunc) {
+    return k.hub().Watch(prefix)
+}
+
+// Size returns the number of keys in the keyspace
+func (k *memoryKeyspace) Size() int64 {
+    k.db.mu.RLock()
+    defer k.db.mu.RUnlock()
+
+    bkt, err := k.bucket()
+    if err != nil {
+        return 0
+    }
+    return int64(len(bkt))
+}
+
+// ForEach iterates over all the key value pairs in the keyspace
+func (k *memoryKeyspace) ForEach(each ItemHandler) error {
+    k.db.mu.RLock()
+    bkt, err := k.bucket()
+    if err != nil {
+        k.db.mu.RUnlock()
+        return err
+    }
+
+    // snapshot so the callback can't deadlock by re-entering the keyspace
+    keys := sortedKeys(bkt)
+    values := make([][]byte, len(keys))
+    for i, key := range keys {
+        values[i] = bkt[key]
+    }
+    k.db.mu.RUnlock()
+
+    for i, key := range keys {
+        if err := each([]byte(key), values[i]); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// Contains determines if the given key exists in the keyspace
+func (k *memoryKeyspace) Contains(key string) (bool, error) {
+    k.db.mu.RLock()
+    defer k.db.mu.RUnlock()
+
+    bkt, err := k.bucket()
+    if err != nil {
+        return false, err
+    }
+
+    _, ok := bkt[key]
+    return ok, nil
+}
+
+// ReadTx allows for more complex read operations on the keyspace through a backend-neutral Tx
+func (k *memoryKeyspace) ReadTx(callback func(Tx) error) error {
+    k.db.mu.RLock()
+    defer k.db.mu.RUnlock()
+
+    bkt, err := k.bucket()
+    if err != nil {
+        return err
+    }
+    return callback(&memoryTx{bkt})
+}
+
+// WriteTx allows for more complex write operations on the keyspace through a backend-neutral Tx
+func (k *memoryKeyspace) WriteTx(callback func(Tx) error) error {
+    k.db.mu.Lock()
+    defer k.db.mu.Unlock()
+
+    bkt, err := k.bucket()
+    if err != nil {
+        return err
+    }
+    return callback(&memoryTx{bkt})
+}
+
+// GetOrCreateChild returns a nested keyspace scoped under this one, creating it if it doesn't exist
+func (k *memoryKeyspace) GetOrCreateChild(name string) (Keyspace, error) {
+    k.db.mu.Lock()
+    defer k.db.mu.Unlock()
+
+    childPath := k.path + encodeSegment(name)
+    if _, ok := k.db.data[childPath]; !ok {
+        k.db.data[childPath] = make(map[string][]byte)
+    }
+    return &memoryKeyspace{name: name, path: childPath, db: k.db}, nil
+}
+
+// DeleteChild removes a nested keyspace, and any of its own nested children, from this one
+func (k *memoryKeyspace) DeleteChild(name string) error {
+    childPath := k.path + encodeSegment(name)
+
+    k.db.mu.Lock()
+    for p := range k.db.data {
+        if strings.HasPrefix(p, childPath) {
+            delete(k.db.data, p)
+            k.db.hubs.closeAndDelete(p)
+        }
+    }
+    k.db.mu.Unlock()
+
+    k.db.hubs.closeAndDelete(childPath)
+    return nil
+}
+
+// ForEachChild iterates over all the direct child keyspaces nested under this one
+func (k *memoryKeyspace) ForEachChild(each func(name string, ks Keyspace) error) error {
+    k.db.mu.RLock()
+    prefix := k.path
+
+    var children []string
+    for path := range k.db.data {
+        if path == prefix || !strings.HasPrefix(path, prefix) {
+            continue
+        }
+        // only direct children: exactly one more encoded segment, nothing left over
+        name, rest, ok := decodeSegment(path[len(prefix):])
+        if !ok || rest != "" {
+            continue
+        }
+        children = append(children, name)
+    }
+    k.db.mu.RUnlock()
+
+    sort.Strings(children)
+    for _, name := range children {
+        if err := each(name, &memoryKeyspace{name: name, path: prefix + encodeSegment(name), db: k.db}); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// Iterator returns an Iterator over the range [start, end) of the keyspace
+func (k *memoryKeyspace) Iterator(start, end []byte) (Iterator, error) {
+    k.db.mu.RLock()
+    defer k.db.mu.RUnlock()
+
+    bkt, err := k.bucket()
+    if err != nil {
+        return nil, err
+    }
+    return newMemoryIterator(bkt, start, end, false), nil
+}
+
+// ReverseIterator returns an Iterator over the range [start, end) of the keyspace,
+// moving from the last matching key to the first
+func (k *memoryKeyspace) ReverseIterator(start, end []byte) (Iterator, error) {
+    k.db.mu.RLock()
+    defer k.db.mu.RUnlock()
+
+    bkt, err := k.bucket()
+    if err != nil {
+        return nil, err
+    }
+    return newMemoryIterator(bkt, start, end, true), nil
+}
+
+// PrefixIterator returns an Iterator over every key in the keyspace beginning with prefix
+func (k *memoryKeyspace) PrefixIterator(prefix []byte) (Iterator, error) {
+    return k.Iterator(prefix, prefixRangeEnd(prefix))
+}
+
+// NewBatch returns a Batch that queues mutations to be committed atomically
+func (k *memoryKeyspace) NewBatch() Batch {
+    return &memoryBatch{ks: k}
+}
+
+// sortedKeys returns the keys of bkt in lexicographic order
+func sortedKeys(bkt map[string][]byte) []string {
+    keys := make([]string, 0, len(bkt))
+    for key := range bkt {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    return keys
+}
+
+// memoryTx adapts a keyspace's backing map to the backend-neutral Tx interface
+type memoryTx struct {
+    bkt map[string][]byte
+}
+
+// Get returns the value for key, or ErrKeyNotFound if it doesn't exist
+func (t *memoryTx) Get(key []byte) ([]byte, error) {
+    value, ok := t.bkt[string(key)]
+    if !ok {
+        return nil, ErrKeyNotFound
+    }
+    return value, nil
+}
+
+// Put sets key to value
+func (t *memoryTx) Put(key, value []byte) error {
+    t.bkt[string(key)] = value
+    return nil
+}
+
+// Delete removes key
+func (t *memoryTx) Delete(key []byte) error {
+    delete(t.bkt, string(key))
+    return nil
+}
+
+// Cursor returns a Cursor over the bucket backing this Tx
+func (t *memoryTx) Cursor() Cursor {
+    return newMemoryCursor(t.bkt)
+}
+
+// memoryCursor is a Cursor over a sorted snapshot of a keyspace's keys, taken at the time
+// the cursor was created.
+type memoryCursor struct {
+    keys   []string
+    values [][]byte
+    idx    int
+}
+
+// newMemoryCursor snapshots bkt in sorted key order
+func newMemoryCursor(bkt map[string][]byte) *memoryCursor {
+    keys := sortedKeys(bkt)
+    values := make([][]byte, len(keys))
+    for i, key := range keys {
+        values[i] = bkt[key]
+    }
+    return &memoryCursor{keys: keys, values: values, idx: -1}
+}
+
+func (c *memoryCursor) at(i int) ([]byte, []byte) {
+    if i < 0 || i >= len(c.keys) {
+        c.idx = len(c.keys)
+        return nil, nil
+    }
+    c.idx = i
+    return []byte(c.keys[i]), c.values[i]
+}
+
+// First moves the cursor to the first key/value pair and returns it
+func (c *memoryCursor) First() ([]byte, []byte) {
+    return c.at(0)
+}
+
+// Last moves the cursor to the last key/value pair and returns it
+func (c *memoryCursor) Last() ([]byte, []byte) {
+    return c.at(len(c.keys) - 1)
+}
+
+// Seek moves the cursor to the first key/value pair whose key is >= seek
+func (c *memoryCursor) Seek(seek []byte) ([]byte, []byte) {
+    i := sort.Search(len(c.keys), func(i int) bool {
+        return bytes.Compare([]byte(c.keys[i]), seek) >= 0
+    })
+    return c.at(i)
+}
+
+// Next moves the cursor to the next key/value pair and returns it
+func (c *memoryCursor) Next() ([]byte, []byte) {
+    return c.at(c.idx + 1)
+}
+
+// Prev moves the cursor to the previous key/value pair and returns it
+func (c *memoryCursor) Prev() ([]byte, []byte) {
+    return c.at(c.idx - 1)
+}
+
+// newMemoryIterator builds an Iterator over a sorted snapshot of bkt restricted to [start, end)
+func newMemoryIterator(bkt map[string][]byte, start, end []byte, reverse bool) Iterator {
+    keys := sortedKeys(bkt)
+
+    filtered := make([]string, 0, len(keys))
+    for _, key := range keys {
+        kb := []byte(key)
+        if start != nil && bytes.Compare(kb, start) < 0 {
+            continue
+        }
+        if end != nil && bytes.Compare(kb, end) >= 0 {
+            continue
+        }
+        filtered = append(filtered, key)
+    }
+
+    if reverse {
+        for i, j := 0, len(filtered)-1; i < j; i, j = i+1, j-1 {
+            filtered[i], filtered[j] = filtered[j], filtered[i]
+        }
+    }
+
+    values := make([][]byte, len(filtered))
+    for i, key := range filtered {
+        values[i] = bkt[key]
+    }
+
+    return &memoryIterator{keys: filtered, values: values}
+}
+
+// memoryIterator implements Iterator over an in-memory snapshot of keys and values
+type memoryIterator struct {
+    keys   []string
+    values [][]byte
+    idx    int
+}
+
+// Valid returns whether the iterator is positioned at a valid key/value pair
+func (it *memoryIterator) Valid() bool {
+    return it.idx < len(it.keys)
+}
+
+// Next advances the iterator to the next key/value pair
+func (it *memoryIterator) Next() {
+    if it.Valid() {
+        it.idx++
+    }
+}
+
+// Key returns the key at the current iterator position
+func (it *memoryIterator) Key() []byte {
+    if !it.Valid() {
+        return nil
+    }
+    return []byte(it.keys[it.idx])
+}
+
+// Value returns the value at the current iterator position
+func (it *memoryIterator) Value() []byte {
+    if !it.Valid() {
+        return nil
+    }
+    return it.values[it.idx]
+}
+
+// Error returns any error encountered while iterating
+func (it *memoryIterator) Error() error {
+    return nil
+}
+
+// Close is a no-op, since the iterator holds no resources beyond its snapshot
+func (it *memoryIterator) Close() error {
+    return nil
+}
+
+// memoryBatchOp is a single queued mutation in a memoryBatch
+type memoryBatchOp struct {
+    delete bool
+    key    string
+    value  []byte
+}
+
+// memoryBatch implements Batch on top of a memoryKeyspace
+type memoryBatch struct {
+    ks  *memoryKeyspace
+    ops []memoryBatchOp
+}
+
+// Set queues a key to be set to value when the batch is written
+func (batch *memoryBatch) Set(key string, value []byte) {
+    batch.ops = append(batch.ops, memoryBatchOp{key: key, value: value})
+}
+
+// Delete queues a key to be removed when the batch is written
+func (batch *memoryBatch) Delete(key string) {
+    batch.ops = append(batch.ops, memoryBatchOp{delete: true, key: key})
+}
+
+// Write commits all queued mutations atomically, then fans out a watch Event for each one
+func (batch *memoryBatch) Write() error {
+    batch.ks.db.mu.Lock()
+    defer batch.ks.db.mu.Unlock()
+
+    bkt, err := batch.ks.bucket()
+    if err != nil {
+        return err
+    }
+
+    events := make([]Event, 0, len(batch.ops))
+    for _, op := range batch.ops {
+        prev := bkt[op.key]
+
+        if op.delete {
+            delete(bkt, op.key)
+            events = append(events, Event{Type: EventDelete, Key: []byte(op.key), PrevValue: prev})
+            continue
+        }
+
+        bkt[op.key] = op.value
+        events = append(events, Event{Type: EventPut, Key: []byte(op.key), Value: op.value, PrevValue: prev})
+    }
+
+    hub := batch.ks.hub()
+    for _, e := range events {
+        hub.emit(e)
+    }
+    return nil
+}
+
+// Close discards the batch, releasing any queued mutations
+func (batch *memoryBatch) Close() {
+    batch.ops = nil
+}
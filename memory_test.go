@@ -0,0 +1,191 @@
+package leaf
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestMemoryDBCreateKeyspace(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+    assert.NotNil(t, ks)
+    assert.Equal(t, "users", ks.GetName())
+
+    err = ks.Insert("user1", []byte("1"))
+    assert.Nil(t, err)
+
+    value, err := ks.Get("user1")
+    assert.Nil(t, err)
+    assert.Equal(t, []byte("1"), value)
+
+    _, err = ks.Get("user2")
+    assert.Equal(t, ErrKeyNotFound, err)
+
+    db.DeleteKeyspace("users")
+    _, err = ks.Get("user1")
+    assert.Equal(t, ErrChildNotFound, err)
+}
+
+func TestMemoryKeyspaceChildren(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    child, err := ks.GetOrCreateChild("sessions")
+    assert.Nil(t, err)
+    assert.Equal(t, "sessions", child.GetName())
+
+    err = child.Insert("session1", []byte("active"))
+    assert.Nil(t, err)
+
+    found := make(map[string]bool)
+    err = ks.ForEachChild(func(name string, c Keyspace) error {
+        found[name] = true
+        return nil
+    })
+    assert.Nil(t, err)
+    assert.True(t, found["sessions"])
+
+    err = ks.DeleteChild("sessions")
+    assert.Nil(t, err)
+
+    _, err = child.Get("session1")
+    assert.Equal(t, ErrChildNotFound, err)
+}
+
+func TestMemoryKeyspaceIterator(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    for _, key := range []string{"user1", "user2", "user3", "user4"} {
+        assert.Nil(t, ks.Insert(key, []byte("1")))
+    }
+
+    it, err := ks.Iterator([]byte("user2"), []byte("user4"))
+    assert.Nil(t, err)
+
+    var keys []string
+    for ; it.Valid(); it.Next() {
+        keys = append(keys, string(it.Key()))
+    }
+    assert.Nil(t, it.Close())
+    assert.Equal(t, []string{"user2", "user3"}, keys)
+}
+
+func TestMemoryKeyspaceBatch(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    batch := ks.NewBatch()
+    batch.Set("user1", []byte("1"))
+    batch.Set("user2", []byte("2"))
+    batch.Delete("user1")
+
+    assert.Nil(t, batch.Write())
+    batch.Close()
+
+    _, err = ks.Get("user1")
+    assert.Equal(t, ErrKeyNotFound, err)
+
+    value, err := ks.Get("user2")
+    assert.Nil(t, err)
+    assert.Equal(t, []byte("2"), value)
+}
+
+func TestMemoryDBNameDoesNotCollideWithNestedChild(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    users, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    sessions, err := users.GetOrCreateChild("sessions")
+    assert.Nil(t, err)
+    assert.Nil(t, sessions.Insert("s1", []byte("nested")))
+
+    // a root keyspace whose name happens to spell out the same "/"-joined path must not
+    // share storage with the genuinely nested child above
+    collider, err := db.GetOrCreateKeyspace("users/sessions")
+    assert.Nil(t, err)
+
+    _, err = collider.Get("s1")
+    assert.Equal(t, ErrKeyNotFound, err)
+
+    assert.Nil(t, collider.Insert("s1", []byte("root")))
+
+    value, err := sessions.Get("s1")
+    assert.Nil(t, err)
+    assert.Equal(t, []byte("nested"), value)
+}
+
+func TestMemoryKeyspaceReadWriteTx(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    err = ks.WriteTx(func(tx Tx) error {
+        if err := tx.Put([]byte("user1"), []byte("Ada")); err != nil {
+            return err
+        }
+        return tx.Put([]byte("user2"), []byte("Grace"))
+    })
+    assert.Nil(t, err)
+
+    err = ks.ReadTx(func(tx Tx) error {
+        value, err := tx.Get([]byte("user1"))
+        assert.Nil(t, err)
+        assert.Equal(t, []byte("Ada"), value)
+
+        cursor := tx.Cursor()
+        k, v := cursor.First()
+        assert.Equal(t, []byte("user1"), k)
+        assert.Equal(t, []byte("Ada"), v)
+
+        k, v = cursor.Next()
+        assert.Equal(t, []byte("user2"), k)
+        assert.Equal(t, []byte("Grace"), v)
+
+        return nil
+    })
+    assert.Nil(t, err)
+
+    err = ks.WriteTx(func(tx Tx) error {
+        return tx.Delete([]byte("user1"))
+    })
+    assert.Nil(t, err)
+
+    err = ks.ReadTx(func(tx Tx) error {
+        _, err := tx.Get([]byte("user1"))
+        assert.Equal(t, ErrKeyNotFound, err)
+        return nil
+    })
+    assert.Nil(t, err)
+}
+
+func TestOpenBackend(t *testing.T) {
+    db, err := OpenBackend("memory", Options{})
+    assert.Nil(t, err)
+    assert.NotNil(t, db)
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+    assert.NotNil(t, ks)
+
+    _, err = OpenBackend("does-not-exist", Options{})
+    assert.Equal(t, ErrUnknownBackend, err)
+}
@@ -0,0 +1,156 @@
+package leaf
+
+import (
+    "strings"
+    "sync"
+)
+
+// defaultWatchBufferSize bounds how many events a single subscriber channel can queue
+// before the "slow subscriber" drop policy kicks in.
+const defaultWatchBufferSize = 64
+
+// EventType describes the kind of mutation a watch Event represents.
+type EventType int
+
+const (
+    // EventPut is emitted after a key is inserted or updated
+    EventPut EventType = iota
+
+    // EventDelete is emitted after a key is removed
+    EventDelete
+)
+
+// Event describes a single mutation to a watched key.
+type Event struct {
+    Type      EventType
+    Key       []byte
+    Value     []byte
+    PrevValue []byte
+}
+
+// CancelFunc unsubscribes a watch, closing its event channel.
+type CancelFunc func()
+
+// hubRegistry hands out a shared watchHub per keyspace path, so every Keyspace value
+// resolving to the same underlying bucket (e.g. from repeated GetOrCreateKeyspace calls)
+// fans mutation events out to the same subscribers.
+type hubRegistry struct {
+    mu   sync.Mutex
+    hubs map[string]*watchHub
+}
+
+func newHubRegistry() *hubRegistry {
+    return &hubRegistry{hubs: make(map[string]*watchHub)}
+}
+
+// get returns the watchHub for path, creating it on first use
+func (r *hubRegistry) get(path string) *watchHub {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    hub, ok := r.hubs[path]
+    if !ok {
+        hub = newWatchHub()
+        r.hubs[path] = hub
+    }
+    return hub
+}
+
+// closeAndDelete tears down the hub for path and every nested child path beneath it,
+// closing their subscribers' channels, after that keyspace has been deleted.
+func (r *hubRegistry) closeAndDelete(path string) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    prefix := path + "/"
+    for p, hub := range r.hubs {
+        if p == path || strings.HasPrefix(p, prefix) {
+            hub.closeAll()
+            delete(r.hubs, p)
+        }
+    }
+}
+
+// watchHub fans mutation events out to every subscriber whose prefix matches. writeMu
+// serializes a keyspace's commit-then-emit sequence across concurrent writers, so
+// subscribers observe events in the same order the mutations were actually applied.
+type watchHub struct {
+    mu   sync.Mutex
+    subs []*watchSub
+
+    writeMu sync.Mutex
+}
+
+type watchSub struct {
+    prefix string
+    ch     chan Event
+    once   sync.Once
+}
+
+// close closes the subscriber's channel, safe to call more than once or concurrently
+// with the CancelFunc returned from Watch.
+func (s *watchSub) close() {
+    s.once.Do(func() { close(s.ch) })
+}
+
+func newWatchHub() *watchHub {
+    return &watchHub{}
+}
+
+// Watch registers a subscriber for every future event whose key matches prefix. Events
+// are delivered over a bounded, buffered channel; a subscriber that falls behind has
+// events dropped rather than blocking the writer that triggered them.
+func (h *watchHub) Watch(prefix string) (<-chan Event, CancelFunc) {
+    sub := &watchSub{prefix: prefix, ch: make(chan Event, defaultWatchBufferSize)}
+
+    h.mu.Lock()
+    h.subs = append(h.subs, sub)
+    h.mu.Unlock()
+
+    cancel := func() {
+        h.mu.Lock()
+        for i, s := range h.subs {
+            if s == sub {
+                h.subs = append(h.subs[:i], h.subs[i+1:]...)
+                break
+            }
+        }
+        h.mu.Unlock()
+        sub.close()
+    }
+    return sub.ch, cancel
+}
+
+// closeAll unsubscribes and closes the channel of every current subscriber
+func (h *watchHub) closeAll() {
+    h.mu.Lock()
+    subs := h.subs
+    h.subs = nil
+    h.mu.Unlock()
+
+    for _, sub := range subs {
+        sub.close()
+    }
+}
+
+// emit fans e out to every subscriber whose prefix matches e.Key, dropping the event for
+// any subscriber whose channel is full. Callers that need emitted events to reflect the
+// true commit order should hold writeMu across their mutation and this call.
+func (h *watchHub) emit(e Event) {
+    key := string(e.Key)
+
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    for _, sub := range h.subs {
+        if !strings.HasPrefix(key, sub.prefix) {
+            continue
+        }
+
+        select {
+        case sub.ch <- e:
+        default:
+            // slow subscriber: drop the event rather than block the writer
+        }
+    }
+}
@@ -0,0 +1,214 @@
+package leaf
+
+import (
+    "os"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+)
+
+func TestMemoryKeyspaceWatchPutAndDelete(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    events, cancel := ks.Watch("user")
+    defer cancel()
+
+    assert.Nil(t, ks.Insert("user1", []byte("1")))
+    assert.Nil(t, ks.Delete("user1")) // EventDelete should carry user1's old value
+
+    select {
+    case e := <-events:
+        assert.Equal(t, EventPut, e.Type)
+        assert.Equal(t, []byte("user1"), e.Key)
+        assert.Equal(t, []byte("1"), e.Value)
+        assert.Nil(t, e.PrevValue)
+    case <-time.After(time.Second):
+        t.Fatal("timed out waiting for put event")
+    }
+
+    select {
+    case e := <-events:
+        assert.Equal(t, EventDelete, e.Type)
+        assert.Equal(t, []byte("user1"), e.Key)
+        assert.Equal(t, []byte("1"), e.PrevValue)
+    case <-time.After(time.Second):
+        t.Fatal("timed out waiting for delete event")
+    }
+}
+
+func TestMemoryKeyspaceWatchPrefixFilter(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    events, cancel := ks.Watch("user/")
+    defer cancel()
+
+    assert.Nil(t, ks.Insert("group/1", []byte("1")))
+    assert.Nil(t, ks.Insert("user/1", []byte("1")))
+
+    select {
+    case e := <-events:
+        assert.Equal(t, []byte("user/1"), e.Key)
+    case <-time.After(time.Second):
+        t.Fatal("timed out waiting for matching event")
+    }
+
+    select {
+    case <-events:
+        t.Fatal("received an event for a key outside the watched prefix")
+    default:
+    }
+}
+
+func TestMemoryKeyspaceWatchSharedAcrossInstances(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    writer, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    reader, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    events, cancel := reader.Watch("")
+    defer cancel()
+
+    assert.Nil(t, writer.Insert("user1", []byte("1")))
+
+    select {
+    case e := <-events:
+        assert.Equal(t, []byte("user1"), e.Key)
+    case <-time.After(time.Second):
+        t.Fatal("timed out waiting for event from a different Keyspace instance")
+    }
+}
+
+func TestMemoryKeyspaceWatchBatch(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    events, cancel := ks.Watch("")
+    defer cancel()
+
+    batch := ks.NewBatch()
+    batch.Set("user1", []byte("1"))
+    batch.Set("user2", []byte("2"))
+    assert.Nil(t, batch.Write())
+    batch.Close()
+
+    seen := make(map[string]bool)
+    for i := 0; i < 2; i++ {
+        select {
+        case e := <-events:
+            seen[string(e.Key)] = true
+        case <-time.After(time.Second):
+            t.Fatal("timed out waiting for batch events")
+        }
+    }
+    assert.True(t, seen["user1"])
+    assert.True(t, seen["user2"])
+}
+
+func TestMemoryKeyspaceWatchCancel(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    events, cancel := ks.Watch("")
+    cancel()
+
+    assert.Nil(t, ks.Insert("user1", []byte("1")))
+
+    _, ok := <-events
+    assert.False(t, ok)
+}
+
+func TestMemoryKeyspaceWatchDeleteKeyspaceClosesChannel(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    events, cancel := ks.Watch("")
+    defer cancel()
+
+    assert.Nil(t, db.DeleteKeyspace("users"))
+
+    select {
+    case _, ok := <-events:
+        assert.False(t, ok, "expected channel to be closed after DeleteKeyspace")
+    case <-time.After(time.Second):
+        t.Fatal("timed out waiting for channel to close")
+    }
+}
+
+func TestMemoryKeyspaceWatchDeleteChildClosesChannel(t *testing.T) {
+    db := NewMemoryDB()
+    defer db.Close()
+
+    ks, err := db.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    child, err := ks.GetOrCreateChild("sessions")
+    assert.Nil(t, err)
+
+    events, cancel := child.Watch("")
+    defer cancel()
+
+    assert.Nil(t, ks.DeleteChild("sessions"))
+
+    select {
+    case _, ok := <-events:
+        assert.False(t, ok, "expected channel to be closed after DeleteChild")
+    case <-time.After(time.Second):
+        t.Fatal("timed out waiting for channel to close")
+    }
+}
+
+func TestBoltKeyspaceWatch(t *testing.T) {
+    file := os.TempDir() + "/leaf.db"
+
+    leaf, err := NewLeaf(file)
+    defer leaf.Close()
+    assert.Nil(t, err)
+
+    ks, err := leaf.GetOrCreateKeyspace("users")
+    assert.Nil(t, err)
+
+    events, cancel := ks.Watch("")
+    defer cancel()
+
+    assert.Nil(t, ks.Insert("user1", []byte("1")))
+
+    select {
+    case e := <-events:
+        assert.Equal(t, EventPut, e.Type)
+        assert.Equal(t, []byte("user1"), e.Key)
+        assert.Equal(t, []byte("1"), e.Value)
+    case <-time.After(time.Second):
+        t.Fatal("timed out waiting for put event")
+    }
+
+    assert.Nil(t, leaf.DeleteKeyspace("users"))
+
+    select {
+    case _, ok := <-events:
+        assert.False(t, ok, "expected channel to be closed after DeleteKeyspace")
+    case <-time.After(time.Second):
+        t.Fatal("timed out waiting for channel to close")
+    }
+}